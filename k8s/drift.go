@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// fieldPathSet is a nested set of field-manager field paths, as decoded from
+// a managedFields entry's fieldsV1 document (e.g. {"f:spec":{"f:replicas":{}}}).
+type fieldPathSet map[string]fieldPathSet
+
+// projectLiveFields reduces live down to only the fields the user manages, so
+// a computed live_manifest surfaces drift only in fields the user actually
+// set - not whatever else a controller (autoscaler, admission webhook, ...)
+// has added to the object.
+//
+// When ssa is true the fields come from live's metadata.managedFields entry
+// for fieldManager; otherwise they come from walking desired's own keys.
+func projectLiveFields(desired, live *unstructured.Unstructured, ssa bool) (map[string]interface{}, error) {
+	if ssa {
+		paths, err := managedFieldPaths(live, fieldManager)
+		if err != nil {
+			return nil, err
+		}
+		return projectPaths(live.Object, paths), nil
+	}
+
+	if desired == nil {
+		// we don't know the manifest's shape (e.g. right after import) - there's
+		// nothing to project by yet.
+		return map[string]interface{}{}, nil
+	}
+
+	return projectByShape(desired.Object, live.Object), nil
+}
+
+// projectByShape keeps only the keys desired also has, recursing into nested
+// objects so e.g. spec.replicas doesn't pull in the rest of spec.
+func projectByShape(desired, live map[string]interface{}) map[string]interface{} {
+	projected := make(map[string]interface{}, len(desired))
+
+	for key, desiredValue := range desired {
+		liveValue, ok := live[key]
+		if !ok {
+			continue
+		}
+
+		if desiredMap, ok := desiredValue.(map[string]interface{}); ok {
+			if liveMap, ok := liveValue.(map[string]interface{}); ok {
+				projected[key] = projectByShape(desiredMap, liveMap)
+				continue
+			}
+		}
+
+		// lists are compared as a whole rather than element-by-element: list
+		// semantics (merge keys, ordering) vary per field and aren't worth
+		// reimplementing here just to decide what counts as "managed".
+		projected[key] = liveValue
+	}
+
+	return projected
+}
+
+// managedFieldPaths collects every field path owned by manager across all of
+// live's managedFields entries (there can be more than one, e.g. a separate
+// entry per subresource).
+func managedFieldPaths(live *unstructured.Unstructured, manager string) (fieldPathSet, error) {
+	entries, found, err := unstructured.NestedSlice(live.Object, "metadata", "managedFields")
+	if err != nil || !found {
+		return nil, err
+	}
+
+	merged := fieldPathSet{}
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok || m["manager"] != manager {
+			continue
+		}
+
+		fieldsV1, ok := m["fieldsV1"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		merged.mergeFrom(fieldsV1)
+	}
+
+	return merged, nil
+}
+
+// mergeFrom walks one managedFields fieldsV1 document into the path set.
+// "f:" keys are field names; "." (self) and "k:"/"v:" (list element
+// selectors) aren't field names we can project a map key by, so they're left
+// out rather than guessed at.
+func (s fieldPathSet) mergeFrom(fieldsV1 map[string]interface{}) {
+	for key, value := range fieldsV1 {
+		if !strings.HasPrefix(key, "f:") {
+			continue
+		}
+		name := strings.TrimPrefix(key, "f:")
+
+		child, ok := s[name]
+		if !ok {
+			child = fieldPathSet{}
+			s[name] = child
+		}
+
+		if nested, ok := value.(map[string]interface{}); ok {
+			child.mergeFrom(nested)
+		}
+	}
+}
+
+func projectPaths(live map[string]interface{}, paths fieldPathSet) map[string]interface{} {
+	projected := make(map[string]interface{}, len(paths))
+
+	for name, children := range paths {
+		liveValue, ok := live[name]
+		if !ok {
+			continue
+		}
+
+		if len(children) > 0 {
+			if liveMap, ok := liveValue.(map[string]interface{}); ok {
+				projected[name] = projectPaths(liveMap, children)
+				continue
+			}
+		}
+
+		projected[name] = liveValue
+	}
+
+	return projected
+}
+
+// manifestSHA256 hashes a projected manifest's canonical JSON form, so
+// live_manifest_sha256 changes if and only if live_manifest does.
+func manifestSHA256(object map[string]interface{}) (string, error) {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,221 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestProjectByShape(t *testing.T) {
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+		},
+	}
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			// injected by a controller (e.g. an HPA) - not in desired, so
+			// it must not leak into the projection.
+			"selector": "app=foo",
+		},
+		"status": map[string]interface{}{
+			"observedGeneration": int64(1),
+		},
+	}
+
+	got := projectByShape(desired, live)
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("projectByShape() = %#v, want %#v", got, want)
+	}
+}
+
+func TestProjectByShapeKeepsListsWhole(t *testing.T) {
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{map[string]interface{}{"port": int64(80)}},
+		},
+	}
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ports": []interface{}{map[string]interface{}{"port": int64(80), "nodePort": int64(31000)}},
+		},
+	}
+
+	got := projectByShape(desired, live)
+
+	gotPorts := got["spec"].(map[string]interface{})["ports"]
+	livePorts := live["spec"].(map[string]interface{})["ports"]
+	if !reflect.DeepEqual(gotPorts, livePorts) {
+		t.Errorf("projectByShape() list field = %#v, want the live list verbatim %#v", gotPorts, livePorts)
+	}
+}
+
+func TestFieldPathSetMergeFrom(t *testing.T) {
+	fieldsV1 := map[string]interface{}{
+		"f:metadata": map[string]interface{}{
+			"f:labels": map[string]interface{}{
+				"f:app": map[string]interface{}{},
+			},
+		},
+		"f:spec": map[string]interface{}{
+			".":          map[string]interface{}{},
+			"f:replicas": map[string]interface{}{},
+		},
+		"k:ignored-list-selector": map[string]interface{}{},
+	}
+
+	s := fieldPathSet{}
+	s.mergeFrom(fieldsV1)
+
+	want := fieldPathSet{
+		"metadata": fieldPathSet{
+			"labels": fieldPathSet{
+				"app": fieldPathSet{},
+			},
+		},
+		"spec": fieldPathSet{
+			"replicas": fieldPathSet{},
+		},
+	}
+
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("mergeFrom() = %#v, want %#v", s, want)
+	}
+}
+
+func TestManagedFieldPaths(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"managedFields": []interface{}{
+				map[string]interface{}{
+					"manager": fieldManager,
+					"fieldsV1": map[string]interface{}{
+						"f:spec": map[string]interface{}{
+							"f:replicas": map[string]interface{}{},
+						},
+					},
+				},
+				map[string]interface{}{
+					"manager": "kube-controller-manager",
+					"fieldsV1": map[string]interface{}{
+						"f:status": map[string]interface{}{},
+					},
+				},
+			},
+		},
+	}}
+
+	paths, err := managedFieldPaths(live, fieldManager)
+	if err != nil {
+		t.Fatalf("managedFieldPaths() error = %s", err)
+	}
+
+	want := fieldPathSet{"spec": fieldPathSet{"replicas": fieldPathSet{}}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("managedFieldPaths() = %#v, want %#v (other managers' fields must not leak in)", paths, want)
+	}
+}
+
+func TestManagedFieldPathsNoEntries(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{}}
+
+	paths, err := managedFieldPaths(live, fieldManager)
+	if err != nil {
+		t.Fatalf("managedFieldPaths() error = %s", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("managedFieldPaths() = %#v, want empty", paths)
+	}
+}
+
+func TestProjectPaths(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"selector": "app=foo",
+		},
+		"status": map[string]interface{}{
+			"readyReplicas": int64(3),
+		},
+	}
+	paths := fieldPathSet{
+		"spec": fieldPathSet{"replicas": fieldPathSet{}},
+	}
+
+	got := projectPaths(live, paths)
+	want := map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(3)},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("projectPaths() = %#v, want %#v", got, want)
+	}
+}
+
+func TestManifestSHA256IsStableAndSensitiveToContent(t *testing.T) {
+	a := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}}
+	b := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}}
+	c := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(4)}}
+
+	shaA, err := manifestSHA256(a)
+	if err != nil {
+		t.Fatalf("manifestSHA256() error = %s", err)
+	}
+	shaB, err := manifestSHA256(b)
+	if err != nil {
+		t.Fatalf("manifestSHA256() error = %s", err)
+	}
+	shaC, err := manifestSHA256(c)
+	if err != nil {
+		t.Fatalf("manifestSHA256() error = %s", err)
+	}
+
+	if shaA != shaB {
+		t.Errorf("manifestSHA256() not stable for equal input: %s != %s", shaA, shaB)
+	}
+	if shaA == shaC {
+		t.Errorf("manifestSHA256() did not change for different input")
+	}
+}
+
+func TestProjectLiveFieldsNonSSAUsesDesiredShape(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(1)},
+	}}
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(3)},
+		"status": map[string]interface{}{"readyReplicas": int64(3)},
+	}}
+
+	got, err := projectLiveFields(desired, live, false)
+	if err != nil {
+		t.Fatalf("projectLiveFields() error = %s", err)
+	}
+
+	want := map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("projectLiveFields() = %#v, want %#v", got, want)
+	}
+}
+
+func TestProjectLiveFieldsNonSSAWithoutDesiredIsEmpty(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(3)},
+	}}
+
+	got, err := projectLiveFields(nil, live, false)
+	if err != nil {
+		t.Fatalf("projectLiveFields() error = %s", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("projectLiveFields() with no desired shape = %#v, want empty", got)
+	}
+}
@@ -9,6 +9,11 @@ import (
 
 const idSeparator = "::"
 
+// objectIDSeparator joins the per-object IDs of a multi-document manifest
+// into the resource's own ID. The "objects" computed attribute is a TypeList,
+// so this separator only shows up in d.Id().
+const objectIDSeparator = "|"
+
 func idParts(id string) (string, string, string, string, error) {
 	parts := strings.Split(id, idSeparator)
 	if len(parts) != 4 {
@@ -31,6 +36,23 @@ func buildId(object *unstructured.Unstructured) string {
 	)
 }
 
+// buildObjectIDs returns the buildId-form identifier of every object in a
+// manifest set, in the order given.
+func buildObjectIDs(objects []*unstructured.Unstructured) []string {
+	ids := make([]string, len(objects))
+	for i, object := range objects {
+		ids[i] = buildId(object)
+	}
+	return ids
+}
+
+// buildMultiId packs the IDs of every object in a manifest set into a single
+// resource ID, so a manifest resource backed by several Kubernetes objects
+// still has one Terraform-level ID.
+func buildMultiId(objects []*unstructured.Unstructured) string {
+	return strings.Join(buildObjectIDs(objects), objectIDSeparator)
+}
+
 func expandStringSlice(s []interface{}) []string {
 	result := make([]string, len(s), len(s))
 	for k, v := range s {
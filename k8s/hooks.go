@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hook_delete_policy values, matching Helm's helm.sh/hook-delete-policy
+// annotation.
+const (
+	hookDeletePolicyBeforeCreation = "before-hook-creation"
+	hookDeletePolicySucceeded      = "hook-succeeded"
+	hookDeletePolicyFailed         = "hook-failed"
+)
+
+// hookContentSchema is the schema for pre_create/post_create/pre_delete/
+// post_delete: an optional YAML (or multi-document YAML) manifest, mirroring
+// Helm hook annotations but expressed as its own attribute instead of an
+// annotation on the main manifest. Like "namespace", changing a hook forces a
+// new resource rather than trying to reconcile an already-fired hook.
+func hookContentSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ForceNew:     true,
+		ValidateFunc: validation.StringIsNotEmpty,
+	}
+}
+
+// hookObjectsSchema tracks the IDs of the objects a hook last created, the
+// same way "objects" tracks the main manifest's, so re-runs can find and
+// garbage-collect them instead of leaking Jobs/ConfigMaps.
+func hookObjectsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// runHookPhase applies the named hook (one of pre_create, post_create,
+// pre_delete, post_delete), if set, the same way the main manifest is
+// applied: dependency-ordered, waited on for readiness. It then garbage
+// collects the hook's objects according to hook_delete_policy.
+func runHookPhase(d *schema.ResourceData, c client.Client, applyMethod, hookName string, timeout time.Duration) error {
+	content, ok := d.GetOk(hookName)
+	if !ok {
+		return nil
+	}
+
+	objects, err := contentToObject(content.(string))
+	if err != nil {
+		return fmt.Errorf("invalid %s manifest: %s", hookName, err)
+	}
+	applyNamespaceDefault(objects, d.Get("namespace").(string))
+
+	policy := d.Get("hook_delete_policy").(string)
+	objectsKey := hookName + "_objects"
+
+	if policy == hookDeletePolicyBeforeCreation {
+		if err := deleteHookObjects(d, c, objectsKey, timeout); err != nil {
+			return err
+		}
+	}
+
+	applyErr := applyHookObjects(d, c, applyMethod, objects, timeout)
+
+	if err := d.Set(objectsKey, buildObjectIDs(objects)); err != nil {
+		return err
+	}
+
+	switch {
+	case applyErr != nil && policy == hookDeletePolicyFailed:
+		if err := deleteObjectsList(c, objects, timeout); err != nil {
+			log.Printf("[DEBUG] failed to clean up %s objects after failure: %#v", hookName, err)
+		}
+	case applyErr == nil && policy == hookDeletePolicySucceeded:
+		if err := deleteObjectsList(c, objects, timeout); err != nil {
+			return err
+		}
+	}
+
+	return applyErr
+}
+
+// applyHookObjects creates (or server-side applies) a hook's objects in
+// install order, waiting for readiness between phases - the same apply loop
+// resourceK8sManifestCreate uses for the main manifest.
+func applyHookObjects(d *schema.ResourceData, c client.Client, applyMethod string, objects []*unstructured.Unstructured, timeout time.Duration) error {
+	for _, phase := range phasesByInstallOrder(objects) {
+		for _, object := range phase {
+			log.Printf("[INFO] Creating hook object: %#v", object)
+			if err := createOrApply(c, applyMethod, object); err != nil {
+				return err
+			}
+		}
+		for _, object := range phase {
+			if err := waitForReadyStatus(d, c, object, timeout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// deleteHookObjects deletes whatever a hook created on a prior run, as
+// tracked by the objectsKey computed attribute.
+func deleteHookObjects(d *schema.ResourceData, c client.Client, objectsKey string, timeout time.Duration) error {
+	raw, ok := d.GetOk(objectsKey)
+	if !ok {
+		return nil
+	}
+
+	ids := expandStringSlice(raw.([]interface{}))
+	objects := make([]*unstructured.Unstructured, 0, len(ids))
+	for _, id := range ids {
+		object, err := objectFromId(id)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object)
+	}
+
+	return deleteObjectsList(c, objects, timeout)
+}
+
+// deleteObjectsList tears down objects in reverse install order, the same
+// way resourceK8sManifestDelete does for the main manifest.
+func deleteObjectsList(c client.Client, objects []*unstructured.Unstructured, timeout time.Duration) error {
+	for _, phase := range reversedPhases(phasesByInstallOrder(objects)) {
+		for _, object := range phase {
+			if err := deleteObjectAndWait(c, object, timeout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
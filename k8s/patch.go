@@ -33,6 +33,41 @@ import (
 var k8sNativeScheme *runtime.Scheme
 var k8sNativeSchemeOnce sync.Once
 
+// the two values the resource's apply_method attribute accepts.
+const (
+	applyMethodClientSide = "client-side"
+	applyMethodServerSide = "server-side"
+)
+
+// fieldManager identifies this provider's field ownership to the API server
+// when applying with server-side apply.
+const fieldManager = "terraform-provider-k8s"
+
+// createOrApply creates object the same way resourceK8sManifestCreate always
+// has, unless apply_method is server-side, in which case it server-side
+// applies instead - which both creates and (on a later run) updates, so it
+// stays the entry point for new objects in both modes.
+func createOrApply(c client.Client, applyMethod string, object *unstructured.Unstructured) error {
+	if applyMethod == applyMethodServerSide {
+		return applyServerSide(c, object)
+	}
+	return c.Create(context.Background(), object)
+}
+
+// applyServerSide sends target to the API server as a Server-Side Apply
+// patch, owned by fieldManager, forcing ownership of any field already
+// managed by another field manager. This replaces the three-way
+// strategicpatch/jsonpatch bookkeeping in patch/createPatch below for callers
+// that opt into apply_method = "server-side": there's no "original vs current
+// vs modified" to compute, and it works for CRDs that strategic merge can't
+// handle.
+func applyServerSide(c client.Client, target *unstructured.Unstructured) error {
+	if err := c.Patch(context.TODO(), target, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return errors.Wrapf(err, "cannot server-side apply %q with kind %s", target.GetName(), target.GroupVersionKind().String())
+	}
+	return nil
+}
+
 func patch(c client.Client, target, original, current *unstructured.Unstructured) error {
 	patch, patchType, err := createPatch(target, original, current)
 	if err != nil {
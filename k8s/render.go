@@ -0,0 +1,169 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+)
+
+// resolveContent returns the manifest YAML content k8s_manifest should apply;
+// callers hash it with renderedContentSHA256 for the rendered_content_sha256
+// computed attribute. Exactly one of "content", "kustomize" or "template" is set
+// (enforced by their ExactlyOneOf schema); content is used as-is, while
+// kustomize and template render their input into the same YAML stream
+// contentToObject already knows how to parse.
+func resolveContent(d contentGetter) (string, error) {
+	if raw, ok := d.GetOk("content"); ok {
+		return raw.(string), nil
+	}
+
+	if raw, ok := d.GetOk("kustomize"); ok {
+		block := raw.([]interface{})[0].(map[string]interface{})
+		content, err := renderKustomize(block["path"].(string))
+		if err != nil {
+			return "", fmt.Errorf("rendering kustomize manifests: %s", err)
+		}
+		return content, nil
+	}
+
+	if raw, ok := d.GetOk("template"); ok {
+		block := raw.([]interface{})[0].(map[string]interface{})
+
+		namespace := "default"
+		if raw, ok := d.GetOk("namespace"); ok {
+			namespace = raw.(string)
+		}
+
+		content, err := renderChart(block["chart"].(string), namespace, block["values"].(map[string]interface{}))
+		if err != nil {
+			return "", fmt.Errorf("rendering chart template: %s", err)
+		}
+		return content, nil
+	}
+
+	return "", fmt.Errorf("one of \"content\", \"kustomize\" or \"template\" must be set")
+}
+
+// contentGetter is the subset of *schema.ResourceData resolveContent needs,
+// kept narrow so *schema.ResourceDiff (in resourceK8sManifestCustomizeDiff)
+// satisfies it too.
+type contentGetter interface {
+	GetOk(key string) (interface{}, bool)
+}
+
+// resolveContentChange returns the content k8s_manifest last applied
+// ("original", used by resourceK8sManifestUpdate's three-way patch to tell what the user
+// stopped managing) and the content it should apply now ("modified").
+//
+// For plain "content" this is exactly the attribute's own before/after, same
+// as always. kustomize and template have no literal attribute to diff - the
+// rendered YAML isn't something Terraform tracks - so their "original" is
+// rendered_content as it was left by the prior Create/Update instead.
+func resolveContentChange(d *schema.ResourceData) (string, string, error) {
+	if _, ok := d.GetOk("content"); ok {
+		old, new := d.GetChange("content")
+		return old.(string), new.(string), nil
+	}
+
+	newContent, err := resolveContent(d)
+	if err != nil {
+		return "", "", err
+	}
+
+	return d.Get("rendered_content").(string), newContent, nil
+}
+
+// resourceK8sManifestCustomizeDiff re-renders kustomize/template sources at
+// plan time and marks rendered_content/_sha256 as changing whenever the
+// rendered output did. Without this, Terraform's diff is computed purely
+// from tracked attributes (kustomize.path, template.chart/values); editing
+// the overlay or chart files those point at wouldn't show up as a plan
+// change at all, defeating rendered_content_sha256's whole purpose.
+func resourceK8sManifestCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if _, ok := d.GetOk("content"); ok {
+		// content is a plain tracked attribute - Terraform's own diff
+		// already detects changes to it.
+		return nil
+	}
+
+	content, err := resolveContent(d)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("rendered_content_sha256").(string) == renderedContentSHA256(content) {
+		return nil
+	}
+
+	if err := d.SetNewComputed("rendered_content"); err != nil {
+		return err
+	}
+	return d.SetNewComputed("rendered_content_sha256")
+}
+
+// renderKustomize runs the kustomize engine against path on the local
+// filesystem, the same way the kustomize CLI would, and returns the
+// resulting objects serialized as a single multi-document YAML stream.
+func renderKustomize(path string) (string, error) {
+	kustomizer := krusty.MakeKustomizer(filesys.MakeFsOnDisk(), krusty.MakeDefaultOptions())
+
+	resMap, err := kustomizer.Run(path)
+	if err != nil {
+		return "", err
+	}
+
+	yamlBytes, err := resMap.AsYaml()
+	if err != nil {
+		return "", err
+	}
+
+	return string(yamlBytes), nil
+}
+
+// renderChart renders chart locally via the Helm SDK in dry-run, client-only
+// mode - no Tiller-era or live-cluster dependency, just the same templating
+// "helm template" does - and returns the rendered manifest plus any chart
+// hooks as a single multi-document YAML stream.
+//
+// namespace becomes the chart's {{ .Release.Namespace }}, matching the
+// resource's own "namespace" attribute so objects that template their
+// metadata.namespace from it land where applyNamespaceDefault expects.
+func renderChart(chartPath, namespace string, values map[string]interface{}) (string, error) {
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(new(action.Configuration))
+	install.ReleaseName = "release-name"
+	install.Namespace = namespace
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = true
+
+	release, err := install.Run(chrt, values)
+	if err != nil {
+		return "", err
+	}
+
+	manifest := release.Manifest
+	for _, hook := range release.Hooks {
+		manifest += "\n---\n" + hook.Manifest
+	}
+
+	return manifest, nil
+}
+
+// renderedContentSHA256 hashes content so rendered_content_sha256 changes if
+// and only if the effective manifest (however it was produced) changes.
+func renderedContentSHA256(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
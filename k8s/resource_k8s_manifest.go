@@ -2,7 +2,9 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"time"
@@ -10,23 +12,36 @@ import (
 	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
-	"github.com/mitchellh/mapstructure"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/kubectl/pkg/polymorphichelpers"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// clusterScopedKinds lists the kinds commonly found in multi-document
+// manifests that are never namespaced, so namespace defaulting (below)
+// leaves them alone.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"CustomResourceDefinition": true,
+	"PersistentVolume":         true,
+	"StorageClass":             true,
+	"IngressClass":             true,
+	"APIService":               true,
+}
+
 func resourceK8sManifest() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceK8sManifestCreate,
-		Read:   resourceK8sManifestRead,
-		Update: resourceK8sManifestUpdate,
-		Delete: resourceK8sManifestDelete,
+		Create:        resourceK8sManifestCreate,
+		Read:          resourceK8sManifestRead,
+		Update:        resourceK8sManifestUpdate,
+		Delete:        resourceK8sManifestDelete,
+		CustomizeDiff: resourceK8sManifestCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: resourceK8sManifestImport,
 		},
@@ -39,15 +54,136 @@ func resourceK8sManifest() *schema.Resource {
 			},
 			"content": {
 				Type:         schema.TypeString,
-				Required:     true,
+				Optional:     true,
 				Sensitive:    false,
 				ValidateFunc: validation.StringIsNotEmpty,
+				ExactlyOneOf: []string{"content", "kustomize", "template"},
+			},
+			"kustomize": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: []string{"content", "kustomize", "template"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"path": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+					},
+				},
+			},
+			"template": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				ExactlyOneOf: []string{"content", "kustomize", "template"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"chart": {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringIsNotEmpty,
+						},
+						// like a plain map[string]string in any other resource here,
+						// values are always strings - a chart that branches on a
+						// boolean- or number-looking value needs to convert it itself
+						// (e.g. with Sprig's "eq .Values.enabled \"true\"").
+						"values": {
+							Type:     schema.TypeMap,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
 			},
 			"delete_cascade": {
 				Type:      schema.TypeBool,
 				Optional:  true,
 				Sensitive: false,
 			},
+			"apply_method": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      applyMethodClientSide,
+				ValidateFunc: validation.StringInSlice([]string{applyMethodClientSide, applyMethodServerSide}, false),
+			},
+			"pre_create":  hookContentSchema(),
+			"post_create": hookContentSchema(),
+			"pre_delete":  hookContentSchema(),
+			"post_delete": hookContentSchema(),
+			"hook_delete_policy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  hookDeletePolicyBeforeCreation,
+				ValidateFunc: validation.StringInSlice([]string{
+					hookDeletePolicyBeforeCreation,
+					hookDeletePolicySucceeded,
+					hookDeletePolicyFailed,
+				}, false),
+			},
+			"pre_create_objects":  hookObjectsSchema(),
+			"post_create_objects": hookObjectsSchema(),
+			"pre_delete_objects":  hookObjectsSchema(),
+			"post_delete_objects": hookObjectsSchema(),
+			"objects": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "namespace::groupVersion::kind::name identifier of every object produced by content, in install order",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"rendered_content": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "content as last applied, or the YAML produced by kustomize/template if one of those is set instead of content",
+			},
+			"rendered_content_sha256": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"live_manifest": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "JSON projection of each object's fields (see \"objects\") as last read from the cluster, restricted to the fields this resource manages",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"live_uid": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"live_resource_version": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"live_manifest_sha256": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"wait": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"for": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "rollout",
+							ValidateFunc: validateWaitFor,
+						},
+						"timeout": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "5m",
+							ValidateFunc: validateWaitTimeout,
+						},
+					},
+				},
+			},
 		},
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(5 * time.Minute),
@@ -60,42 +196,102 @@ func resourceK8sManifest() *schema.Resource {
 func resourceK8sManifestCreate(d *schema.ResourceData, config interface{}) error {
 
 	namespace := d.Get("namespace").(string)
-	content := d.Get("content").(string)
 
-	object, err := contentToObject(content)
+	content, err := resolveContent(d)
 	if err != nil {
 		return err
 	}
 
-	objectNamespace := object.GetNamespace()
+	objects, err := contentToObject(content)
+	if err != nil {
+		return err
+	}
 
-	if namespace == "" && objectNamespace == "" {
-		object.SetNamespace("default")
-	} else if objectNamespace == "" {
-		// TODO: which namespace should have a higher precedence?
-		object.SetNamespace(namespace)
+	applyNamespaceDefault(objects, namespace)
+
+	if err := d.Set("rendered_content", content); err != nil {
+		return err
+	}
+	if err := d.Set("rendered_content_sha256", renderedContentSHA256(content)); err != nil {
+		return err
 	}
 
-	client := config.(*ProviderConfig).RuntimeClient
+	applyMethod := d.Get("apply_method").(string)
+	k8sClient := config.(*ProviderConfig).RuntimeClient
 
-	log.Printf("[INFO] Creating new manifest: %#v", object)
-	err = client.Create(context.Background(), object)
-	if err != nil {
+	if err := runHookPhase(d, k8sClient, applyMethod, "pre_create", d.Timeout(schema.TimeoutCreate)); err != nil {
 		return err
 	}
 
-	// this must stand before the wait to avoid losing state on error
-	d.SetId(buildId(object))
+	phases := phasesByInstallOrder(objects)
+	installOrdered := flattenPhases(phases)
+	for _, phase := range phases {
+		for _, object := range phase {
+			log.Printf("[INFO] Creating new manifest member: %#v", object)
+			if err := createOrApply(k8sClient, applyMethod, object); err != nil {
+				return err
+			}
 
-	err = waitForReadyStatus(d, client, object, d.Timeout(schema.TimeoutCreate))
-	if err != nil {
+			// this must stand before the wait to avoid losing state on error
+			d.SetId(buildMultiId(installOrdered))
+			if err := d.Set("objects", buildObjectIDs(installOrdered)); err != nil {
+				return err
+			}
+		}
+
+		for _, object := range phase {
+			if err := waitForReadyStatus(d, k8sClient, object, d.Timeout(schema.TimeoutCreate)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := runHookPhase(d, k8sClient, applyMethod, "post_create", d.Timeout(schema.TimeoutCreate)); err != nil {
 		return err
 	}
 
 	return resourceK8sManifestRead(d, config)
 }
 
-func waitForReadyStatus(d *schema.ResourceData, c client.Client, object *unstructured.Unstructured, timeout time.Duration) error {
+// applyNamespaceDefault fills in a namespace for every namespaced object that
+// doesn't already carry one, the same way the single-document resource always
+// has: the resource's own "namespace" attribute if set, otherwise "default".
+func applyNamespaceDefault(objects []*unstructured.Unstructured, namespace string) {
+	for _, object := range objects {
+		if clusterScopedKinds[object.GetKind()] || object.GetNamespace() != "" {
+			continue
+		}
+
+		if namespace == "" {
+			object.SetNamespace("default")
+			continue
+		}
+
+		// TODO: which namespace should have a higher precedence?
+		object.SetNamespace(namespace)
+	}
+}
+
+// waitForReadyStatus blocks until object satisfies the resource's wait.for
+// condition, as computed by waitSpec.isReady - a condition-based engine
+// modeled on kstatus/kubectl rollout. For the default "rollout" mode the
+// kind-specific StatusViewer path (Deployment, StatefulSet, DaemonSet, ...)
+// is used in preference to conditions for kinds that have one.
+func waitForReadyStatus(d *schema.ResourceData, c client.Client, object *unstructured.Unstructured, resourceTimeout time.Duration) error {
+	spec, err := waitSpecFromResourceData(d)
+	if err != nil {
+		return err
+	}
+
+	if spec.For == waitForNone {
+		return nil
+	}
+
+	timeout := resourceTimeout
+	if spec.Timeout > 0 {
+		timeout = spec.Timeout
+	}
+
 	objectKey, err := client.ObjectKeyFromObject(object)
 	if err != nil {
 		log.Printf("[DEBUG] Received error: %#v", err)
@@ -118,75 +314,14 @@ func waitForReadyStatus(d *schema.ResourceData, c client.Client, object *unstruc
 
 			log.Printf("[DEBUG] Received object: %#v", object)
 
-			if s, ok := object.Object["status"]; ok {
-				log.Printf("[DEBUG] Object has status: %#v", s)
-
-				if statusViewer, err := polymorphichelpers.StatusViewerFor(object.GetObjectKind().GroupVersionKind().GroupKind()); err == nil {
-					_, ready, err := statusViewer.Status(object, 0)
-					if err != nil {
-						return nil, "error", err
-					}
-					if ready {
-						return object, "ready", nil
-					}
-					return object, "pending", nil
-				}
-				log.Printf("[DEBUG] Object has no rollout status viewer")
-
-				var status status
-				err = mapstructure.Decode(s, &status)
-				if err != nil {
-					log.Printf("[DEBUG] Received error on decode: %#v", err)
-					return nil, "error", err
-				}
-
-				if status.ReadyReplicas != nil {
-					if *status.ReadyReplicas > 0 {
-						return object, "ready", nil
-					}
-
-					return object, "pending", nil
-				}
-
-				if status.Phase != nil {
-					if *status.Phase == "Active" || *status.Phase == "Bound" || *status.Phase == "Running" || *status.Phase == "Ready" || *status.Phase == "Online" || *status.Phase == "Healthy" {
-						return object, "ready", nil
-					}
-
-					return object, "pending", nil
-				}
-
-				if status.LoadBalancer != nil {
-					// LoadBalancer status may be for an Ingress or a Service having type=LoadBalancer
-					checkLoadBalancer := true
-					if object.GetAPIVersion() == "v1" && object.GetKind() == "Service" {
-						specInterface, ok := object.Object["spec"]
-						if !ok {
-							log.Printf("[DEBUG] Received error on decode: %#v", err)
-							return nil, "error", err
-						}
-						spec, ok := specInterface.(map[string]interface{})
-						if !ok {
-							log.Printf("[DEBUG] Received error on decode: %#v", err)
-							return nil, "error", err
-						}
-						serviceType, ok := spec["type"]
-						if !ok {
-							log.Printf("[DEBUG] Received error on decode: %#v", err)
-							return nil, "error", err
-						}
-						checkLoadBalancer = serviceType == "LoadBalancer"
-					}
-					if checkLoadBalancer {
-						if len(*status.LoadBalancer) > 0 {
-							return object, "ready", nil
-						}
-						return object, "pending", nil
-					}
-				}
+			ready, err := spec.isReady(object)
+			if err != nil {
+				return nil, "error", err
 			}
-
-			return object, "ready", nil
+			if ready {
+				return object, "ready", nil
+			}
+			return object, "pending", nil
 		},
 		Timeout:                   timeout,
 		Delay:                     5 * time.Second,
@@ -202,22 +337,34 @@ func waitForReadyStatus(d *schema.ResourceData, c client.Client, object *unstruc
 	return nil
 }
 
-type status struct {
-	ReadyReplicas *int
-	Phase         *string
-	LoadBalancer  *map[string]interface{}
+// resourceObjectIDs returns the buildId-form identifier of every object
+// currently tracked by the resource, preferring the "objects" computed
+// attribute and falling back to splitting the resource ID (e.g. right after
+// import, before Read has had a chance to populate it).
+func resourceObjectIDs(d *schema.ResourceData) []string {
+	if raw, ok := d.GetOk("objects"); ok {
+		if ids := expandStringSlice(raw.([]interface{})); len(ids) > 0 {
+			return ids
+		}
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	return strings.Split(d.Id(), objectIDSeparator)
 }
 
-func resourceK8sManifestRead(d *schema.ResourceData, config interface{}) error {
-	namespace, gv, kind, name, err := idParts(d.Id())
+func objectFromId(id string) (*unstructured.Unstructured, error) {
+	namespace, gv, kind, name, err := idParts(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	groupVersion, err := k8sschema.ParseGroupVersion(gv)
 	if err != nil {
 		log.Printf("[DEBUG] Invalid group version in resource ID: %#v", err)
-		return err
+		return nil, err
 	}
 
 	object := &unstructured.Unstructured{}
@@ -225,118 +372,255 @@ func resourceK8sManifestRead(d *schema.ResourceData, config interface{}) error {
 	object.SetNamespace(namespace)
 	object.SetName(name)
 
-	objectKey, err := client.ObjectKeyFromObject(object)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return err
+	return object, nil
+}
+
+func resourceK8sManifestRead(d *schema.ResourceData, config interface{}) error {
+	k8sClient := config.(*ProviderConfig).RuntimeClient
+
+	ids := resourceObjectIDs(d)
+
+	desiredByKey := map[string]*unstructured.Unstructured{}
+	if content, err := resolveContent(d); err == nil {
+		// keep rendered_content/_sha256 current on every refresh, not just
+		// Create/Update, so a kustomize overlay or chart template edited on
+		// disk between applies is reflected as soon as it's next read.
+		if err := d.Set("rendered_content", content); err != nil {
+			return err
+		}
+		if err := d.Set("rendered_content_sha256", renderedContentSHA256(content)); err != nil {
+			return err
+		}
+
+		if desiredObjects, err := contentToObject(content); err == nil {
+			applyNamespaceDefault(desiredObjects, d.Get("namespace").(string))
+			for _, object := range desiredObjects {
+				desiredByKey[buildId(object)] = object
+			}
+		}
 	}
+	ssa := d.Get("apply_method").(string) == applyMethodServerSide
 
-	client := config.(*ProviderConfig).RuntimeClient
+	remaining := make([]string, 0, len(ids))
+	liveManifests := make([]string, 0, len(ids))
+	liveUIDs := make([]string, 0, len(ids))
+	liveResourceVersions := make([]string, 0, len(ids))
+	liveSHAs := make([]string, 0, len(ids))
 
-	log.Printf("[INFO] Reading object %s", name)
-	err = client.Get(context.Background(), objectKey, object)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			log.Printf("[INFO] Object missing: %#v", object)
-			d.SetId("")
-			return nil
+	for _, id := range ids {
+		object, err := objectFromId(id)
+		if err != nil {
+			return err
 		}
-		if meta.IsNoMatchError(err) {
-			log.Printf("[INFO] Object kind missing: %#v", object)
-			d.SetId("")
-			return nil
+
+		objectKey, err := client.ObjectKeyFromObject(object)
+		if err != nil {
+			log.Printf("[DEBUG] Received error: %#v", err)
+			return err
 		}
 
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return err
+		log.Printf("[INFO] Reading object %s", object.GetName())
+		err = k8sClient.Get(context.Background(), objectKey, object)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				log.Printf("[INFO] Object missing: %#v", object)
+				continue
+			}
+			if meta.IsNoMatchError(err) {
+				log.Printf("[INFO] Object kind missing: %#v", object)
+				continue
+			}
+
+			log.Printf("[DEBUG] Received error: %#v", err)
+			return err
+		}
+		log.Printf("[INFO] Received object: %#v", object)
+
+		projected, err := projectLiveFields(desiredByKey[id], object, ssa)
+		if err != nil {
+			log.Printf("[DEBUG] Received error projecting live fields: %#v", err)
+			return err
+		}
+		manifestJSON, err := json.Marshal(projected)
+		if err != nil {
+			return err
+		}
+		sha, err := manifestSHA256(projected)
+		if err != nil {
+			return err
+		}
+
+		remaining = append(remaining, id)
+		liveManifests = append(liveManifests, string(manifestJSON))
+		liveUIDs = append(liveUIDs, string(object.GetUID()))
+		liveResourceVersions = append(liveResourceVersions, object.GetResourceVersion())
+		liveSHAs = append(liveSHAs, sha)
 	}
-	log.Printf("[INFO] Received object: %#v", object)
 
-	// TODO: save metadata in terraform state
+	if len(remaining) == 0 {
+		d.SetId("")
+		return nil
+	}
 
-	return nil
+	d.SetId(strings.Join(remaining, objectIDSeparator))
+
+	if err := d.Set("objects", remaining); err != nil {
+		return err
+	}
+	if err := d.Set("live_manifest", liveManifests); err != nil {
+		return err
+	}
+	if err := d.Set("live_uid", liveUIDs); err != nil {
+		return err
+	}
+	if err := d.Set("live_resource_version", liveResourceVersions); err != nil {
+		return err
+	}
+	return d.Set("live_manifest_sha256", liveSHAs)
 }
 
 func resourceK8sManifestUpdate(d *schema.ResourceData, config interface{}) error {
-	namespace, _, _, _, err := idParts(d.Id())
+	namespace := d.Get("namespace").(string)
+
+	oldContent, newContent, err := resolveContentChange(d)
 	if err != nil {
 		return err
 	}
 
-	originalData, newData := d.GetChange("content")
-
-	log.Printf("[DEBUG] Original vs modified: %s %s", originalData, newData)
+	log.Printf("[DEBUG] Original vs modified: %s %s", oldContent, newContent)
 
-	modified, err := contentToObject(newData.(string))
+	originalObjects, err := contentToObject(oldContent)
 	if err != nil {
 		return err
 	}
-
-	original, err := contentToObject(originalData.(string))
+	modifiedObjects, err := contentToObject(newContent)
 	if err != nil {
 		return err
 	}
 
-	objectNamespace := modified.GetNamespace()
+	applyNamespaceDefault(originalObjects, namespace)
+	applyNamespaceDefault(modifiedObjects, namespace)
 
-	if namespace == "" && objectNamespace == "" {
-		modified.SetNamespace("default")
-	} else if objectNamespace == "" {
-		// TODO: which namespace should have a higher precedence?
-		modified.SetNamespace(namespace)
+	originalByKey := make(map[string]*unstructured.Unstructured, len(originalObjects))
+	for _, object := range originalObjects {
+		originalByKey[buildId(object)] = object
 	}
-
-	objectKey, err := client.ObjectKeyFromObject(modified)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return err
+	modifiedByKey := make(map[string]*unstructured.Unstructured, len(modifiedObjects))
+	for _, object := range modifiedObjects {
+		modifiedByKey[buildId(object)] = object
 	}
 
-	current := modified.DeepCopy()
+	applyMethod := d.Get("apply_method").(string)
+	k8sClient := config.(*ProviderConfig).RuntimeClient
 
-	client := config.(*ProviderConfig).RuntimeClient
+	var removed []*unstructured.Unstructured
+	for key, object := range originalByKey {
+		if _, ok := modifiedByKey[key]; !ok {
+			removed = append(removed, object)
+		}
+	}
 
-	err = client.Get(context.Background(), objectKey, current)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return err
+	// members dropped from the manifest are torn down first, reverse install order
+	for _, phase := range reversedPhases(phasesByInstallOrder(removed)) {
+		for _, object := range phase {
+			if err := deleteObjectAndWait(k8sClient, object, d.Timeout(schema.TimeoutUpdate)); err != nil {
+				return err
+			}
+		}
 	}
 
-	modified.SetResourceVersion(current.DeepCopy().GetResourceVersion())
+	for _, phase := range phasesByInstallOrder(modifiedObjects) {
+		for _, object := range phase {
+			if applyMethod == applyMethodServerSide {
+				// no three-way merge bookkeeping needed: the API server computes
+				// the diff against the fields we own and creates the object if it
+				// doesn't exist yet.
+				if err := applyServerSide(k8sClient, object); err != nil {
+					log.Printf("[DEBUG] Received error: %#v", err)
+					return err
+				}
+				log.Printf("[INFO] Server-side applied object: %#v", object)
+				continue
+			}
 
-	current.SetResourceVersion("")
-	original.SetResourceVersion("")
+			key := buildId(object)
 
-	if err := patch(config.(*ProviderConfig).RuntimeClient, modified, original, current); err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
+			objectKey, err := client.ObjectKeyFromObject(object)
+			if err != nil {
+				log.Printf("[DEBUG] Received error: %#v", err)
+				return err
+			}
+
+			current := object.DeepCopy()
+			err = k8sClient.Get(context.Background(), objectKey, current)
+			if apierrors.IsNotFound(err) {
+				log.Printf("[INFO] Creating new manifest member: %#v", object)
+				if err := k8sClient.Create(context.Background(), object); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				log.Printf("[DEBUG] Received error: %#v", err)
+				return err
+			}
+
+			original, existed := originalByKey[key]
+			if !existed {
+				// a member that's new to the manifest but already present on the
+				// cluster (e.g. re-added after being removed in a prior apply)
+				original = object.DeepCopy()
+			}
+
+			object.SetResourceVersion(current.DeepCopy().GetResourceVersion())
+			current.SetResourceVersion("")
+			original.SetResourceVersion("")
+
+			if err := patch(k8sClient, object, original, current); err != nil {
+				log.Printf("[DEBUG] Received error: %#v", err)
+				return err
+			}
+			log.Printf("[INFO] Updated object: %#v", object)
+		}
+
+		for _, object := range phase {
+			if err := waitForReadyStatus(d, k8sClient, object, d.Timeout(schema.TimeoutUpdate)); err != nil {
+				return err
+			}
+		}
+	}
+
+	installOrdered := flattenPhases(phasesByInstallOrder(modifiedObjects))
+	d.SetId(buildMultiId(installOrdered))
+	if err := d.Set("objects", buildObjectIDs(installOrdered)); err != nil {
+		return err
+	}
+	if err := d.Set("rendered_content", newContent); err != nil {
+		return err
+	}
+	if err := d.Set("rendered_content_sha256", renderedContentSHA256(newContent)); err != nil {
 		return err
 	}
-	log.Printf("[INFO] Updated object: %#v", modified)
 
-	return waitForReadyStatus(d, client, modified, d.Timeout(schema.TimeoutUpdate))
+	return resourceK8sManifestRead(d, config)
 }
 
 func resourceK8sManifestDelete(d *schema.ResourceData, config interface{}) error {
-	namespace, gv, kind, name, err := idParts(d.Id())
-	if err != nil {
-		return err
-	}
+	k8sClient := config.(*ProviderConfig).RuntimeClient
+	applyMethod := d.Get("apply_method").(string)
 
-	groupVersion, err := k8sschema.ParseGroupVersion(gv)
-	if err != nil {
-		log.Printf("[DEBUG] Invalid group version in resource ID: %#v", err)
+	if err := runHookPhase(d, k8sClient, applyMethod, "pre_delete", d.Timeout(schema.TimeoutDelete)); err != nil {
 		return err
 	}
 
-	currentObject := &unstructured.Unstructured{}
-	currentObject.SetGroupVersionKind(groupVersion.WithKind(kind))
-	currentObject.SetNamespace(namespace)
-	currentObject.SetName(name)
-
-	objectKey, err := client.ObjectKeyFromObject(currentObject)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return err
+	ids := resourceObjectIDs(d)
+	objects := make([]*unstructured.Unstructured, 0, len(ids))
+	for _, id := range ids {
+		object, err := objectFromId(id)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, object)
 	}
 
 	deleteCascade := d.Get("delete_cascade").(bool)
@@ -345,16 +629,38 @@ func resourceK8sManifestDelete(d *schema.ResourceData, config interface{}) error
 		deleteOptions = append(deleteOptions, client.PropagationPolicy(metav1.DeletePropagationForeground))
 	}
 
-	client := config.(*ProviderConfig).RuntimeClient
+	for _, phase := range reversedPhases(phasesByInstallOrder(objects)) {
+		for _, object := range phase {
+			if err := deleteObjectAndWait(k8sClient, object, d.Timeout(schema.TimeoutDelete), deleteOptions...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return runHookPhase(d, k8sClient, applyMethod, "post_delete", d.Timeout(schema.TimeoutDelete))
+}
 
-	log.Printf("[INFO] Deleting object %s", name)
-	err = client.Delete(context.Background(), currentObject, deleteOptions...)
+// deleteObjectAndWait deletes a single manifest member and waits for it to
+// actually disappear from the API, the same way resourceK8sManifestDelete
+// always has for the single-object case.
+func deleteObjectAndWait(c client.Client, object *unstructured.Unstructured, timeout time.Duration, opts ...client.DeleteOption) error {
+	objectKey, err := client.ObjectKeyFromObject(object)
 	if err != nil {
 		log.Printf("[DEBUG] Received error: %#v", err)
 		return err
 	}
 
-	createStateConf := &resource.StateChangeConf{
+	log.Printf("[INFO] Deleting object %s", object.GetName())
+	err = c.Delete(context.Background(), object, opts...)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		log.Printf("[DEBUG] Received error: %#v", err)
+		return err
+	}
+
+	deleteStateConf := &resource.StateChangeConf{
 		Pending: []string{
 			"deleting",
 		},
@@ -362,63 +668,55 @@ func resourceK8sManifestDelete(d *schema.ResourceData, config interface{}) error
 			"deleted",
 		},
 		Refresh: func() (interface{}, string, error) {
-			err := client.Get(context.Background(), objectKey, currentObject)
+			err := c.Get(context.Background(), objectKey, object)
 			if err != nil {
-				log.Printf("[INFO] error when deleting object %s: %+v", name, err)
+				log.Printf("[INFO] error when deleting object %s: %+v", object.GetName(), err)
 				if apierrors.IsNotFound(err) {
-					return currentObject, "deleted", nil
+					return object, "deleted", nil
 				}
 				return nil, "error", err
 
 			}
-			return currentObject, "deleting", nil
+			return object, "deleting", nil
 		},
-		Timeout:                   d.Timeout(schema.TimeoutDelete),
+		Timeout:                   timeout,
 		Delay:                     5 * time.Second,
 		MinTimeout:                5 * time.Second,
 		ContinuousTargetOccurence: 1,
 	}
 
-	_, err = createStateConf.WaitForState()
+	_, err = deleteStateConf.WaitForState()
 	if err != nil {
-		return fmt.Errorf("Error waiting for resource (%s) to be deleted: %s", d.Id(), err)
+		return fmt.Errorf("Error waiting for resource (%s) to be deleted: %s", object.GetName(), err)
 	}
 
-	log.Printf("[INFO] Deleted object: %#v", currentObject)
+	log.Printf("[INFO] Deleted object: %#v", object)
 
 	return nil
 }
 
 func resourceK8sManifestImport(d *schema.ResourceData, config interface{}) ([]*schema.ResourceData, error) {
 
-	namespace, gv, kind, name, err := idParts(d.Id())
-	if err != nil {
-		return nil, err
-	}
-
-	groupVersion, err := k8sschema.ParseGroupVersion(gv)
-	if err != nil {
-		log.Printf("[DEBUG] Invalid group version in resource ID: %#v", err)
-		return nil, err
-	}
-
-	object := &unstructured.Unstructured{}
-	object.SetGroupVersionKind(groupVersion.WithKind(kind))
-	object.SetNamespace(namespace)
-	object.SetName(name)
+	k8sClient := config.(*ProviderConfig).RuntimeClient
 
-	objectKey, err := client.ObjectKeyFromObject(object)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return nil, err
-	}
+	ids := strings.Split(d.Id(), objectIDSeparator)
+	for _, id := range ids {
+		object, err := objectFromId(id)
+		if err != nil {
+			return nil, err
+		}
 
-	client := config.(*ProviderConfig).RuntimeClient
+		objectKey, err := client.ObjectKeyFromObject(object)
+		if err != nil {
+			log.Printf("[DEBUG] Received error: %#v", err)
+			return nil, err
+		}
 
-	err = client.Get(context.Background(), objectKey, object)
-	if err != nil {
-		log.Printf("[DEBUG] Received error: %#v", err)
-		return nil, err
+		err = k8sClient.Get(context.Background(), objectKey, object)
+		if err != nil {
+			log.Printf("[DEBUG] Received error: %#v", err)
+			return nil, err
+		}
 	}
 
 	resource := schema.ResourceData{}
@@ -427,20 +725,35 @@ func resourceK8sManifestImport(d *schema.ResourceData, config interface{}) ([]*s
 	return []*schema.ResourceData{&resource}, nil
 }
 
-func contentToObject(content string) (*unstructured.Unstructured, error) {
+// contentToObject parses content as a stream of one or more YAML (or JSON)
+// documents separated by "---", returning every object it contains.
+func contentToObject(content string) ([]*unstructured.Unstructured, error) {
 	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(content), 4096)
 
-	var object *unstructured.Unstructured
+	var objects []*unstructured.Unstructured
 
 	for {
+		var object *unstructured.Unstructured
+
 		err := decoder.Decode(&object)
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
 			return nil, fmt.Errorf("Failed to unmarshal manifest: %s", err)
 		}
 
-		if object != nil {
-			return object, nil
+		// an empty document (e.g. a leading/trailing "---") decodes to nil
+		if object == nil {
+			continue
 		}
+
+		objects = append(objects, object)
 	}
-}
 
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("content does not contain any objects")
+	}
+
+	return objects, nil
+}
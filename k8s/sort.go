@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// installOrder mirrors Helm's kind install ordering (pkg/releaseutil/kind_sorter.go):
+// resources that other resources depend on (namespaces, CRDs, RBAC, config) are
+// applied first, workloads last. Kinds that aren't listed sort after everything
+// that is, preserving their relative (file) order.
+var installOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"ConfigMap",
+	"Secret",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"StorageClass",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+var kindPriority = func() map[string]int {
+	m := make(map[string]int, len(installOrder))
+	for i, kind := range installOrder {
+		m[kind] = i
+	}
+	return m
+}()
+
+// priorityOf returns the install-order weight for kind, sorting anything
+// unlisted after all known kinds.
+func priorityOf(kind string) int {
+	if p, ok := kindPriority[kind]; ok {
+		return p
+	}
+	return len(installOrder)
+}
+
+// sortByInstallOrder stable-sorts objects by Helm-style install priority so
+// that e.g. a Namespace or CustomResourceDefinition always applies before
+// anything that lives inside it.
+func sortByInstallOrder(objects []*unstructured.Unstructured) []*unstructured.Unstructured {
+	sorted := make([]*unstructured.Unstructured, len(objects))
+	copy(sorted, objects)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return priorityOf(sorted[i].GetKind()) < priorityOf(sorted[j].GetKind())
+	})
+
+	return sorted
+}
+
+// reversedPhases returns phases in the opposite order, keeping each phase's
+// members together. Used to tear a manifest set down in the reverse of the
+// order it was applied in.
+func reversedPhases(phases [][]*unstructured.Unstructured) [][]*unstructured.Unstructured {
+	reversed := make([][]*unstructured.Unstructured, len(phases))
+	for i, phase := range phases {
+		reversed[len(phases)-1-i] = phase
+	}
+	return reversed
+}
+
+// flattenPhases concatenates phases back into a single install-ordered slice,
+// for callers (like the "objects" computed attribute) that want the order
+// applied rather than the grouping it was applied in.
+func flattenPhases(phases [][]*unstructured.Unstructured) []*unstructured.Unstructured {
+	var flattened []*unstructured.Unstructured
+	for _, phase := range phases {
+		flattened = append(flattened, phase...)
+	}
+	return flattened
+}
+
+// phasesByInstallOrder groups objects that share an install priority into the
+// same phase, so the caller can apply a phase at a time and wait for
+// readiness before moving to the next one (e.g. a CRD must be Established
+// before an instance of it can be created).
+func phasesByInstallOrder(objects []*unstructured.Unstructured) [][]*unstructured.Unstructured {
+	sorted := sortByInstallOrder(objects)
+
+	var phases [][]*unstructured.Unstructured
+	for _, object := range sorted {
+		if len(phases) == 0 || priorityOf(phases[len(phases)-1][0].GetKind()) != priorityOf(object.GetKind()) {
+			phases = append(phases, []*unstructured.Unstructured{object})
+			continue
+		}
+		phases[len(phases)-1] = append(phases[len(phases)-1], object)
+	}
+
+	return phases
+}
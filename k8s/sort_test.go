@@ -0,0 +1,163 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newObjectWithKind(kind, name string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetKind(kind)
+	object.SetName(name)
+	return object
+}
+
+func kindsOf(objects []*unstructured.Unstructured) []string {
+	kinds := make([]string, len(objects))
+	for i, object := range objects {
+		kinds[i] = object.GetKind()
+	}
+	return kinds
+}
+
+func TestPriorityOf(t *testing.T) {
+	tests := []struct {
+		kind string
+		want int
+	}{
+		{"Namespace", 0},
+		{"ConfigMap", kindPriority["ConfigMap"]},
+		{"Deployment", kindPriority["Deployment"]},
+		{"SomeCustomResource", len(installOrder)},
+		{"", len(installOrder)},
+	}
+
+	for _, tt := range tests {
+		if got := priorityOf(tt.kind); got != tt.want {
+			t.Errorf("priorityOf(%q) = %d, want %d", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestSortByInstallOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{
+			name: "workload before its namespace",
+			in:   []string{"Deployment", "Namespace"},
+			want: []string{"Namespace", "Deployment"},
+		},
+		{
+			name: "already sorted",
+			in:   []string{"Namespace", "ConfigMap", "Deployment"},
+			want: []string{"Namespace", "ConfigMap", "Deployment"},
+		},
+		{
+			name: "unknown kinds sort last and keep relative order",
+			in:   []string{"Widget", "Gadget", "ConfigMap"},
+			want: []string{"ConfigMap", "Widget", "Gadget"},
+		},
+		{
+			name: "same-kind entries are grouped ahead of later kinds",
+			in:   []string{"ConfigMap", "Secret", "ConfigMap"},
+			want: []string{"ConfigMap", "ConfigMap", "Secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := make([]*unstructured.Unstructured, len(tt.in))
+			for i, kind := range tt.in {
+				objects[i] = newObjectWithKind(kind, "")
+			}
+
+			sorted := sortByInstallOrder(objects)
+
+			if got := kindsOf(sorted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortByInstallOrder(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+
+			// the input slice itself must be untouched
+			if got := kindsOf(objects); !reflect.DeepEqual(got, tt.in) {
+				t.Errorf("sortByInstallOrder mutated its input: got %v, want %v", got, tt.in)
+			}
+		})
+	}
+}
+
+func TestPhasesByInstallOrder(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newObjectWithKind("Deployment", "web"),
+		newObjectWithKind("Namespace", "ns"),
+		newObjectWithKind("ConfigMap", "cm"),
+		newObjectWithKind("Secret", "sec"),
+	}
+
+	phases := phasesByInstallOrder(objects)
+
+	var gotKinds [][]string
+	for _, phase := range phases {
+		gotKinds = append(gotKinds, kindsOf(phase))
+	}
+
+	want := [][]string{
+		{"Namespace"},
+		{"ConfigMap"},
+		{"Secret"},
+		{"Deployment"},
+	}
+
+	if !reflect.DeepEqual(gotKinds, want) {
+		t.Errorf("phasesByInstallOrder() phases = %v, want %v", gotKinds, want)
+	}
+}
+
+func TestReversedPhases(t *testing.T) {
+	phases := [][]*unstructured.Unstructured{
+		{newObjectWithKind("Namespace", "ns")},
+		{newObjectWithKind("ConfigMap", "cm"), newObjectWithKind("Secret", "sec")},
+		{newObjectWithKind("Deployment", "web")},
+	}
+
+	reversed := reversedPhases(phases)
+
+	var gotKinds [][]string
+	for _, phase := range reversed {
+		gotKinds = append(gotKinds, kindsOf(phase))
+	}
+
+	want := [][]string{
+		{"Deployment"},
+		{"ConfigMap", "Secret"},
+		{"Namespace"},
+	}
+
+	if !reflect.DeepEqual(gotKinds, want) {
+		t.Errorf("reversedPhases() = %v, want %v", gotKinds, want)
+	}
+
+	// members within a phase keep their order, only phase order flips
+	if reversed[1][0].GetName() != "cm" || reversed[1][1].GetName() != "sec" {
+		t.Errorf("reversedPhases() reordered members within a phase: %v", kindsOf(reversed[1]))
+	}
+}
+
+func TestFlattenPhases(t *testing.T) {
+	phases := [][]*unstructured.Unstructured{
+		{newObjectWithKind("Namespace", "ns")},
+		{newObjectWithKind("ConfigMap", "cm"), newObjectWithKind("Secret", "sec")},
+		{newObjectWithKind("Deployment", "web")},
+	}
+
+	got := kindsOf(flattenPhases(phases))
+	want := []string{"Namespace", "ConfigMap", "Secret", "Deployment"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flattenPhases() = %v, want %v", got, want)
+	}
+}
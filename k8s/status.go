@@ -0,0 +1,309 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
+)
+
+// the values the resource's wait.for attribute accepts: a fixed "rollout" or
+// "none", or a "condition=<Type>" / "jsonpath=<expr>" with an argument.
+const (
+	waitForRollout         = "rollout"
+	waitForNone            = "none"
+	waitForConditionPrefix = "condition="
+	waitForJSONPathPrefix  = "jsonpath="
+)
+
+// conditionStatus is the reduced, kstatus-style status of an object, derived
+// from its status.conditions[] rather than kind-specific fields. It mirrors
+// sigs.k8s.io/cli-utils/pkg/kstatus, which this provider doesn't depend on,
+// so the names are kept the same for anyone familiar with that project.
+type conditionStatus string
+
+const (
+	statusInProgress  conditionStatus = "InProgress"
+	statusCurrent     conditionStatus = "Current"
+	statusFailed      conditionStatus = "Failed"
+	statusTerminating conditionStatus = "Terminating"
+	statusNotFound    conditionStatus = "NotFound"
+	statusUnknown     conditionStatus = "Unknown"
+)
+
+// standard condition types that kstatus-style engines key off of. Not every
+// object sets every one of these.
+const (
+	conditionReady       = "Ready"
+	conditionAvailable   = "Available"
+	conditionProgressing = "Progressing"
+	conditionReconciling = "Reconciling"
+	conditionStalled     = "Stalled"
+	conditionEstablished = "Established" // CustomResourceDefinition
+)
+
+type condition struct {
+	Type   string
+	Status metav1.ConditionStatus
+}
+
+// conditionStatusOf inspects object's status.conditions (and, if present,
+// metadata.deletionTimestamp / observedGeneration) and reduces them to one of
+// the kstatus-style buckets above. This lets the provider wait on arbitrary
+// CRDs (Certificates, Kustomizations, Applications, ...) whose readiness is
+// encoded in conditions rather than replica counts.
+//
+// An object that has no status.conditions[] at all (e.g. a Service or a
+// PersistentVolumeClaim, which signal readiness some other way entirely) is
+// statusUnknown, and rolloutReady treats that as ready since there's nothing
+// here to wait on. An object that *does* have conditions, but none this
+// engine recognizes - some CRD with its own vocabulary we haven't special-
+// cased - is statusInProgress instead: unlike kstatus's own "Unknown" bucket,
+// we'd rather keep waiting (and eventually time out) than report an object
+// ready that we actually have no idea about.
+func conditionStatusOf(object *unstructured.Unstructured) conditionStatus {
+	if object == nil {
+		return statusNotFound
+	}
+
+	if object.GetDeletionTimestamp() != nil {
+		return statusTerminating
+	}
+
+	conditions := extractConditions(object)
+	if len(conditions) == 0 {
+		return statusUnknown
+	}
+
+	if generation := object.GetGeneration(); generation != 0 {
+		if observed, found, err := unstructured.NestedInt64(object.Object, "status", "observedGeneration"); err == nil && found && observed < generation {
+			return statusInProgress
+		}
+	}
+
+	if c := findCondition(conditions, conditionStalled); c != nil && c.Status == metav1.ConditionTrue {
+		return statusFailed
+	}
+
+	if c := findCondition(conditions, conditionReconciling); c != nil && c.Status == metav1.ConditionTrue {
+		return statusInProgress
+	}
+
+	if c := findCondition(conditions, conditionProgressing); c != nil && c.Status == metav1.ConditionTrue {
+		return statusInProgress
+	}
+
+	// CustomResourceDefinition has no StatusViewer of its own, so without
+	// this every CRD would fall through to the "conditions present but
+	// unrecognized" case below instead of actually waiting to be Established.
+	if c := findCondition(conditions, conditionEstablished); c != nil {
+		if c.Status == metav1.ConditionTrue {
+			return statusCurrent
+		}
+		return statusInProgress
+	}
+
+	if c := findCondition(conditions, conditionReady); c != nil {
+		if c.Status == metav1.ConditionTrue {
+			return statusCurrent
+		}
+		return statusInProgress
+	}
+
+	if c := findCondition(conditions, conditionAvailable); c != nil {
+		if c.Status == metav1.ConditionTrue {
+			return statusCurrent
+		}
+		return statusInProgress
+	}
+
+	return statusInProgress
+}
+
+// extractConditions reads status.conditions[] generically, since unstructured
+// objects don't share a single typed Status we can decode into.
+func extractConditions(object *unstructured.Unstructured) []condition {
+	raw, found, err := unstructured.NestedSlice(object.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil
+	}
+
+	conditions := make([]condition, 0, len(raw))
+	for _, item := range raw {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		c := condition{}
+		if v, ok := m["type"].(string); ok {
+			c.Type = v
+		}
+		if v, ok := m["status"].(string); ok {
+			c.Status = metav1.ConditionStatus(v)
+		}
+		conditions = append(conditions, c)
+	}
+
+	return conditions
+}
+
+func findCondition(conditions []condition, conditionType string) *condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// waitSpec is the parsed form of the resource's "wait" block: what to wait
+// for, and for how long, before a create/update is considered done.
+type waitSpec struct {
+	For     string
+	Timeout time.Duration
+}
+
+// waitSpecFromResourceData reads the (optional, single) "wait" block off the
+// resource. Resources written before "wait" existed get the old, always-on
+// rollout behavior by leaving the block unset.
+func waitSpecFromResourceData(d *schema.ResourceData) (waitSpec, error) {
+	raw, ok := d.GetOk("wait")
+	if !ok {
+		return waitSpec{For: waitForRollout}, nil
+	}
+
+	blocks := raw.([]interface{})
+	if len(blocks) == 0 {
+		return waitSpec{For: waitForRollout}, nil
+	}
+
+	block := blocks[0].(map[string]interface{})
+
+	forMode, _ := block["for"].(string)
+	if forMode == "" {
+		forMode = waitForRollout
+	}
+
+	timeout := 5 * time.Minute
+	if timeoutStr, _ := block["timeout"].(string); timeoutStr != "" {
+		parsed, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return waitSpec{}, fmt.Errorf("invalid wait.timeout %q: %s", timeoutStr, err)
+		}
+		timeout = parsed
+	}
+
+	return waitSpec{For: forMode, Timeout: timeout}, nil
+}
+
+// isReady evaluates the wait spec against the live object, as fetched by the
+// caller on every poll.
+func (w waitSpec) isReady(object *unstructured.Unstructured) (bool, error) {
+	switch {
+	case w.For == "" || w.For == waitForRollout:
+		return rolloutReady(object)
+	case w.For == waitForNone:
+		return true, nil
+	case strings.HasPrefix(w.For, waitForConditionPrefix):
+		conditionType := strings.TrimPrefix(w.For, waitForConditionPrefix)
+		c := findCondition(extractConditions(object), conditionType)
+		return c != nil && c.Status == metav1.ConditionTrue, nil
+	case strings.HasPrefix(w.For, waitForJSONPathPrefix):
+		return jsonPathReady(object, strings.TrimPrefix(w.For, waitForJSONPathPrefix))
+	default:
+		return false, fmt.Errorf("unsupported wait.for %q", w.For)
+	}
+}
+
+// rolloutReady is the default kubectl-rollout-style wait: the kind-specific
+// StatusViewer (Deployment, StatefulSet, DaemonSet, ...) if there is one,
+// otherwise the condition-based engine above, otherwise - for objects with no
+// status subresource at all, e.g. a ConfigMap - there's nothing to wait on.
+func rolloutReady(object *unstructured.Unstructured) (bool, error) {
+	if statusViewer, err := polymorphichelpers.StatusViewerFor(object.GetObjectKind().GroupVersionKind().GroupKind()); err == nil {
+		_, ready, err := statusViewer.Status(object, 0)
+		return ready, err
+	}
+
+	if _, ok := object.Object["status"]; !ok {
+		return true, nil
+	}
+
+	switch conditionStatusOf(object) {
+	case statusCurrent, statusUnknown:
+		return true, nil
+	case statusFailed:
+		return false, fmt.Errorf("object %s is in Failed status", object.GetName())
+	default:
+		return false, nil
+	}
+}
+
+// jsonPathReady evaluates a kubectl-style jsonpath expression (without its
+// surrounding braces) against the live object and treats any non-empty,
+// non-false result as ready.
+func jsonPathReady(object *unstructured.Unstructured, expr string) (bool, error) {
+	jp := jsonpath.New("wait")
+	jp.AllowMissingKeys(true)
+
+	if err := jp.Parse(fmt.Sprintf("{%s}", expr)); err != nil {
+		return false, fmt.Errorf("invalid wait jsonpath %q: %s", expr, err)
+	}
+
+	results, err := jp.FindResults(object.Object)
+	if err != nil {
+		return false, err
+	}
+
+	for _, set := range results {
+		for _, v := range set {
+			switch fmt.Sprintf("%v", v.Interface()) {
+			case "", "false", "<no value>":
+				continue
+			default:
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// validateWaitFor is the ValidateFunc for wait.for: one of the two fixed
+// modes, or a "condition="/"jsonpath=" prefix with an argument.
+func validateWaitFor(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+
+	if value == waitForRollout || value == waitForNone {
+		return nil, nil
+	}
+	if strings.HasPrefix(value, waitForConditionPrefix) && len(value) > len(waitForConditionPrefix) {
+		return nil, nil
+	}
+	if strings.HasPrefix(value, waitForJSONPathPrefix) && len(value) > len(waitForJSONPathPrefix) {
+		return nil, nil
+	}
+
+	return nil, []error{fmt.Errorf(
+		"%q must be %q, %q, %q, or %q, got %q",
+		k, waitForRollout, waitForNone, waitForConditionPrefix+"<Type>", waitForJSONPathPrefix+"<expr>", value,
+	)}
+}
+
+// validateWaitTimeout is the ValidateFunc for wait.timeout: anything
+// time.ParseDuration accepts, e.g. "30s", "5m".
+func validateWaitTimeout(v interface{}, k string) ([]string, []error) {
+	value := v.(string)
+
+	if _, err := time.ParseDuration(value); err != nil {
+		return nil, []error{fmt.Errorf("%q is not a valid duration: %s", k, err)}
+	}
+
+	return nil, nil
+}
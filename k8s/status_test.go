@@ -0,0 +1,326 @@
+package k8s
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newConditionedObject(generation, observedGeneration int64, conditions ...condition) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	object.SetGeneration(generation)
+
+	var raw []interface{}
+	for _, c := range conditions {
+		raw = append(raw, map[string]interface{}{
+			"type":   c.Type,
+			"status": string(c.Status),
+		})
+	}
+
+	status := map[string]interface{}{}
+	if raw != nil {
+		status["conditions"] = raw
+	}
+	if observedGeneration != 0 {
+		status["observedGeneration"] = observedGeneration
+	}
+	if len(status) > 0 {
+		object.Object["status"] = status
+	}
+
+	return object
+}
+
+func TestConditionStatusOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		object *unstructured.Unstructured
+		want   conditionStatus
+	}{
+		{
+			name:   "nil object is NotFound",
+			object: nil,
+			want:   statusNotFound,
+		},
+		{
+			name:   "no status at all is Unknown",
+			object: newConditionedObject(0, 0),
+			want:   statusUnknown,
+		},
+		{
+			name:   "observedGeneration behind generation is InProgress regardless of conditions",
+			object: newConditionedObject(2, 1, condition{Type: conditionReady, Status: metav1.ConditionTrue}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "Stalled=True is Failed even if Ready=True",
+			object: newConditionedObject(1, 1, condition{Type: conditionReady, Status: metav1.ConditionTrue}, condition{Type: conditionStalled, Status: metav1.ConditionTrue}),
+			want:   statusFailed,
+		},
+		{
+			name:   "Reconciling=True is InProgress",
+			object: newConditionedObject(1, 1, condition{Type: conditionReconciling, Status: metav1.ConditionTrue}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "Progressing=True is InProgress",
+			object: newConditionedObject(1, 1, condition{Type: conditionProgressing, Status: metav1.ConditionTrue}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "Ready=True is Current",
+			object: newConditionedObject(1, 1, condition{Type: conditionReady, Status: metav1.ConditionTrue}),
+			want:   statusCurrent,
+		},
+		{
+			name:   "Ready=False is InProgress",
+			object: newConditionedObject(1, 1, condition{Type: conditionReady, Status: metav1.ConditionFalse}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "Available=True is Current when there's no Ready condition",
+			object: newConditionedObject(1, 1, condition{Type: conditionAvailable, Status: metav1.ConditionTrue}),
+			want:   statusCurrent,
+		},
+		{
+			name:   "Ready takes precedence over Available",
+			object: newConditionedObject(1, 1, condition{Type: conditionAvailable, Status: metav1.ConditionTrue}, condition{Type: conditionReady, Status: metav1.ConditionFalse}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "unrecognized condition types alone are InProgress, not Current",
+			object: newConditionedObject(1, 1, condition{Type: "SomethingElse", Status: metav1.ConditionTrue}),
+			want:   statusInProgress,
+		},
+		{
+			name:   "CRD Established=True is Current",
+			object: newConditionedObject(1, 1, condition{Type: conditionEstablished, Status: metav1.ConditionTrue}),
+			want:   statusCurrent,
+		},
+		{
+			name:   "CRD Established=False is InProgress, not Current",
+			object: newConditionedObject(1, 1, condition{Type: "NamesAccepted", Status: metav1.ConditionTrue}, condition{Type: conditionEstablished, Status: metav1.ConditionFalse}),
+			want:   statusInProgress,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := conditionStatusOf(tt.object); got != tt.want {
+				t.Errorf("conditionStatusOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("deletionTimestamp set is Terminating regardless of conditions", func(t *testing.T) {
+		object := newConditionedObject(1, 1, condition{Type: conditionReady, Status: metav1.ConditionTrue})
+		now := metav1.Now()
+		object.SetDeletionTimestamp(&now)
+
+		if got := conditionStatusOf(object); got != statusTerminating {
+			t.Errorf("conditionStatusOf() = %v, want %v", got, statusTerminating)
+		}
+	})
+}
+
+func TestFindCondition(t *testing.T) {
+	conditions := []condition{
+		{Type: conditionReady, Status: metav1.ConditionTrue},
+		{Type: conditionAvailable, Status: metav1.ConditionFalse},
+	}
+
+	if c := findCondition(conditions, conditionReady); c == nil || c.Status != metav1.ConditionTrue {
+		t.Errorf("findCondition(%q) = %v, want Ready=True", conditionReady, c)
+	}
+
+	if c := findCondition(conditions, "Missing"); c != nil {
+		t.Errorf("findCondition(%q) = %v, want nil", "Missing", c)
+	}
+}
+
+func TestJSONPathReady(t *testing.T) {
+	tests := []struct {
+		name    string
+		object  map[string]interface{}
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "truthy string value",
+			object: map[string]interface{}{"status": map[string]interface{}{"phase": "Running"}},
+			expr:   ".status.phase",
+			want:   true,
+		},
+		{
+			name:   "literal false string is not ready",
+			object: map[string]interface{}{"status": map[string]interface{}{"ready": "false"}},
+			expr:   ".status.ready",
+			want:   false,
+		},
+		{
+			name:   "empty string is not ready",
+			object: map[string]interface{}{"status": map[string]interface{}{"phase": ""}},
+			expr:   ".status.phase",
+			want:   false,
+		},
+		{
+			name:   "missing path is not ready",
+			object: map[string]interface{}{"status": map[string]interface{}{}},
+			expr:   ".status.phase",
+			want:   false,
+		},
+		{
+			name:    "invalid jsonpath expression errors",
+			object:  map[string]interface{}{},
+			expr:    "status.phase[",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := &unstructured.Unstructured{Object: tt.object}
+
+			got, err := jsonPathReady(object, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("jsonPathReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("jsonPathReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolloutReady(t *testing.T) {
+	// ConfigMap has no registered polymorphichelpers.StatusViewer, so these
+	// exercise the condition-based fallback, not the kind-specific path.
+	tests := []struct {
+		name    string
+		object  *unstructured.Unstructured
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "no status subresource is ready immediately",
+			object: newObjectWithKind("ConfigMap", "cm"),
+			want:   true,
+		},
+		{
+			name: "Current status is ready",
+			object: func() *unstructured.Unstructured {
+				o := newConditionedObject(1, 1, condition{Type: conditionReady, Status: metav1.ConditionTrue})
+				o.SetKind("ConfigMap")
+				return o
+			}(),
+			want: true,
+		},
+		{
+			name: "InProgress status is not ready",
+			object: func() *unstructured.Unstructured {
+				o := newConditionedObject(1, 1, condition{Type: conditionProgressing, Status: metav1.ConditionTrue})
+				o.SetKind("ConfigMap")
+				return o
+			}(),
+			want: false,
+		},
+		{
+			name: "Failed status errors",
+			object: func() *unstructured.Unstructured {
+				o := newConditionedObject(1, 1, condition{Type: conditionStalled, Status: metav1.ConditionTrue})
+				o.SetKind("ConfigMap")
+				o.SetName("cm")
+				return o
+			}(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rolloutReady(tt.object)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rolloutReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("rolloutReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitSpecIsReady(t *testing.T) {
+	object := newConditionedObject(1, 1, condition{Type: "Synced", Status: metav1.ConditionTrue})
+	object.SetKind("ConfigMap")
+
+	tests := []struct {
+		name    string
+		spec    waitSpec
+		want    bool
+		wantErr bool
+	}{
+		{name: "none is always ready", spec: waitSpec{For: waitForNone}, want: true},
+		{name: "matching condition is ready", spec: waitSpec{For: waitForConditionPrefix + "Synced"}, want: true},
+		{name: "missing condition is not ready", spec: waitSpec{For: waitForConditionPrefix + "Ready"}, want: false},
+		{name: "unsupported mode errors", spec: waitSpec{For: "bogus"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.spec.isReady(object)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("isReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("isReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWaitFor(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{waitForRollout, false},
+		{waitForNone, false},
+		{"condition=Ready", false},
+		{"jsonpath=.status.phase", false},
+		{"condition=", true},
+		{"jsonpath=", true},
+		{"bogus", true},
+	}
+
+	for _, tt := range tests {
+		_, errs := validateWaitFor(tt.value, "wait.0.for")
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("validateWaitFor(%q) errs = %v, wantErr %v", tt.value, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateWaitTimeout(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"30s", false},
+		{"5m", false},
+		{"not-a-duration", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		_, errs := validateWaitTimeout(tt.value, "wait.0.timeout")
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("validateWaitTimeout(%q) errs = %v, wantErr %v", tt.value, errs, tt.wantErr)
+		}
+	}
+}